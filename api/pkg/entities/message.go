@@ -0,0 +1,63 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MessageStatus represents the status of a Message as it moves through its lifecycle
+type MessageStatus string
+
+const (
+	// MessageStatusPending means the message has been stored but has not been sent yet
+	MessageStatusPending MessageStatus = "PENDING"
+
+	// MessageStatusSent means the message has been sent by the mobile phone
+	MessageStatusSent MessageStatus = "SENT"
+
+	// MessageStatusDelivered means the message has been delivered to the recipient
+	MessageStatusDelivered MessageStatus = "DELIVERED"
+
+	// MessageStatusFailed means the message could not be sent
+	MessageStatusFailed MessageStatus = "FAILED"
+
+	// MessageStatusExpired means the message's send deadline elapsed while it was still MessageStatusPending
+	MessageStatusExpired MessageStatus = "EXPIRED"
+)
+
+// MessageType represents the direction a Message is traveling
+type MessageType string
+
+const (
+	// MessageTypeMobileTerminated is a message sent from the API to a mobile phone
+	MessageTypeMobileTerminated MessageType = "mobile-terminated"
+
+	// MessageTypeMobileOriginated is a message received by a mobile phone
+	MessageTypeMobileOriginated MessageType = "mobile-originated"
+)
+
+// Message represents an SMS message sent or received through the platform
+type Message struct {
+	ID                uuid.UUID
+	UserID            string
+	From              string
+	To                string
+	Content           string
+	Type              MessageType
+	Status            MessageStatus
+	RequestReceivedAt time.Time
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	OrderTimestamp    time.Time
+	SendDuration      *time.Duration
+	LastAttemptedAt   *time.Time
+	SentAt            *time.Time
+	ReceivedAt        *time.Time
+
+	// ExpiresAt is the deadline by which the message must leave MessageStatusPending before the reaper marks it MessageStatusExpired. Nil means the message has no deadline
+	ExpiresAt *time.Time
+
+	// ExpiredAt is set by the reaper when the message transitions to MessageStatusExpired
+	ExpiredAt *time.Time
+}
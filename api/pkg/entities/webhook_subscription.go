@@ -0,0 +1,50 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookSubscription is a user's registration to receive message lifecycle events at a URL
+type WebhookSubscription struct {
+	ID            uuid.UUID
+	UserID        string
+	URL           string
+	EventTypes    []string
+	SigningSecret string
+	// Expression is a CloudEvents SQL (CESQL) expression evaluated against an event before it is delivered; an empty Expression matches every event
+	Expression  string
+	RetryPolicy WebhookRetryPolicy
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// WebhookRetryPolicy controls how a failed webhook delivery is retried
+type WebhookRetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// Matches returns true if eventType is in the subscription's EventTypes filter list
+func (subscription WebhookSubscription) Matches(eventType string) bool {
+	for _, t := range subscription.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDeliveryAttempt records a single attempt to deliver a cloud event to a WebhookSubscription
+type WebhookDeliveryAttempt struct {
+	ID             uuid.UUID
+	SubscriptionID uuid.UUID
+	EventID        string
+	EventType      string
+	StatusCode     int
+	Success        bool
+	Error          string
+	AttemptedAt    time.Time
+}
@@ -0,0 +1,37 @@
+package events
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// EventTypeMessageAPISent is emitted after a message has been received from the httpSMS API and is ready to be sent
+	EventTypeMessageAPISent = "message.api.sent"
+
+	// EventTypeMessageSent is emitted after a mobile phone reports that it sent a message
+	EventTypeMessageSent = "message.sent"
+
+	// EventTypeMessageDelivered is emitted after a message has been delivered to the recipient
+	EventTypeMessageDelivered = "message.delivered"
+
+	// EventTypeMessageFailed is emitted after a message could not be sent
+	EventTypeMessageFailed = "message.failed"
+
+	// EventTypeMessageReceived is emitted after a mobile phone reports an incoming message
+	EventTypeMessageReceived = "message.received"
+
+	// EventTypeMessageExpired is emitted by the reaper when a message's send deadline elapses while it is still pending
+	EventTypeMessageExpired = "message.expired"
+)
+
+// MessageAPISentPayload is the data of the EventTypeMessageAPISent cloud event
+type MessageAPISentPayload struct {
+	ID                uuid.UUID
+	UserID            string
+	From              string
+	To                string
+	Content           string
+	RequestReceivedAt time.Time
+}
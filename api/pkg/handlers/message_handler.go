@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/NdoleStudio/http-sms-manager/pkg/services"
+	"github.com/NdoleStudio/http-sms-manager/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/palantir/stacktrace"
+)
+
+// rawPayloadHeader mirrors Dapr's IsRawPayload metadata flag so carrier gateways can opt out of the CloudEvents envelope
+const rawPayloadHeader = "X-HttpSms-Raw-Payload"
+
+// MessageHandler handles HTTP requests for mobile-originated messages
+type MessageHandler struct {
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.MessageService
+}
+
+// NewMessageHandler creates a new MessageHandler
+func NewMessageHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.MessageService,
+) (h *MessageHandler) {
+	return &MessageHandler{
+		logger:  logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:  tracer,
+		service: service,
+	}
+}
+
+// RegisterRoutes mounts the message routes on router
+func (h *MessageHandler) RegisterRoutes(router *echo.Group) {
+	router.POST("/messages", h.Store)
+}
+
+// messageStoreRequest is the request body for storing a mobile-originated message
+type messageStoreRequest struct {
+	From    string `json:"from" validate:"required"`
+	To      string `json:"to" validate:"required"`
+	Content string `json:"content" validate:"required"`
+}
+
+// Store saves a mobile-originated message. When rawPayloadHeader is set to "true", the CloudEvents envelope is skipped and the message is persisted directly, trading CloudEvents fan-out for lower per-message overhead on bulk ingestion
+// @Summary      Store a message
+// @Tags         Messages
+// @Accept       json
+// @Produce      json
+// @Param        request body messageStoreRequest true "Message"
+// @Success      201 {object} entities.Message
+// @Router       /messages [post]
+func (h *MessageHandler) Store(c echo.Context) error {
+	ctx, span := h.tracer.Start(c.Request().Context())
+	defer span.End()
+
+	var request messageStoreRequest
+	if err := c.Bind(&request); err != nil {
+		msg := fmt.Sprintf("cannot decode request body into %T", request)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)).Error()})
+	}
+
+	rawPayload, _ := strconv.ParseBool(c.Request().Header.Get(rawPayloadHeader))
+
+	message, err := h.service.StoreMessage(ctx, services.MessageStoreParams{
+		ID:                uuid.New(),
+		UserID:            userID(c),
+		From:              request.From,
+		To:                request.To,
+		Content:           request.Content,
+		RequestReceivedAt: time.Now().UTC(),
+		RawPayload:        rawPayload,
+	})
+	if err != nil {
+		msg := "cannot store message"
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)).Error()})
+	}
+
+	return c.JSON(http.StatusCreated, message)
+}
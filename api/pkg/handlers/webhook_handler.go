@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/NdoleStudio/http-sms-manager/pkg/entities"
+	"github.com/NdoleStudio/http-sms-manager/pkg/services"
+	"github.com/NdoleStudio/http-sms-manager/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/palantir/stacktrace"
+)
+
+// WebhookHandler exposes CRUD operations on webhook subscriptions and their delivery log
+type WebhookHandler struct {
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.WebhookService
+}
+
+// NewWebhookHandler creates a new WebhookHandler
+func NewWebhookHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.WebhookService,
+) (h *WebhookHandler) {
+	return &WebhookHandler{
+		logger:  logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:  tracer,
+		service: service,
+	}
+}
+
+// RegisterRoutes mounts the webhook subscription routes on router
+func (h *WebhookHandler) RegisterRoutes(router *echo.Group) {
+	router.POST("/webhook-subscriptions", h.Create)
+	router.GET("/webhook-subscriptions", h.Index)
+	router.PUT("/webhook-subscriptions/:subscriptionID", h.Update)
+	router.DELETE("/webhook-subscriptions/:subscriptionID", h.Delete)
+	router.GET("/webhook-subscriptions/:subscriptionID/deliveries", h.IndexDeliveries)
+}
+
+// webhookSubscriptionCreateRequest is the request body for creating a webhook subscription
+type webhookSubscriptionCreateRequest struct {
+	URL            string   `json:"url" validate:"required,url"`
+	EventTypes     []string `json:"event_types" validate:"required,min=1"`
+	Expression     string   `json:"expression"`
+	MaxAttempts    int      `json:"max_attempts"`
+	InitialBackoff int      `json:"initial_backoff_seconds"`
+	MaxBackoff     int      `json:"max_backoff_seconds"`
+}
+
+// Create registers a new webhook subscription
+// @Summary      Register a webhook subscription
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Param        request body webhookSubscriptionCreateRequest true "Webhook subscription"
+// @Success      201 {object} entities.WebhookSubscription
+// @Router       /webhook-subscriptions [post]
+func (h *WebhookHandler) Create(c echo.Context) error {
+	ctx, span := h.tracer.Start(c.Request().Context())
+	defer span.End()
+
+	var request webhookSubscriptionCreateRequest
+	if err := c.Bind(&request); err != nil {
+		msg := fmt.Sprintf("cannot decode request body into %T", request)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)).Error()})
+	}
+
+	subscription, err := h.service.CreateSubscription(ctx, services.WebhookSubscriptionCreateParams{
+		UserID:      userID(c),
+		URL:         request.URL,
+		EventTypes:  request.EventTypes,
+		Expression:  request.Expression,
+		RetryPolicy: retryPolicy(request.MaxAttempts, request.InitialBackoff, request.MaxBackoff),
+	})
+	if isExpressionInvalid(err) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+	}
+	if err != nil {
+		msg := fmt.Sprintf("cannot create webhook subscription for url [%s]", request.URL)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)).Error()})
+	}
+
+	return c.JSON(http.StatusCreated, subscription)
+}
+
+// webhookSubscriptionUpdateRequest is the request body for updating a webhook subscription
+type webhookSubscriptionUpdateRequest struct {
+	URL            string   `json:"url" validate:"required,url"`
+	EventTypes     []string `json:"event_types" validate:"required,min=1"`
+	Expression     string   `json:"expression"`
+	MaxAttempts    int      `json:"max_attempts"`
+	InitialBackoff int      `json:"initial_backoff_seconds"`
+	MaxBackoff     int      `json:"max_backoff_seconds"`
+}
+
+// Update modifies an existing webhook subscription
+// @Summary      Update a webhook subscription
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Param        subscriptionID path string true "Subscription ID"
+// @Param        request body webhookSubscriptionUpdateRequest true "Webhook subscription"
+// @Success      200 {object} entities.WebhookSubscription
+// @Router       /webhook-subscriptions/{subscriptionID} [put]
+func (h *WebhookHandler) Update(c echo.Context) error {
+	ctx, span := h.tracer.Start(c.Request().Context())
+	defer span.End()
+
+	var request webhookSubscriptionUpdateRequest
+	if err := c.Bind(&request); err != nil {
+		msg := fmt.Sprintf("cannot decode request body into %T", request)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)).Error()})
+	}
+
+	subscription, err := h.service.UpdateSubscription(ctx, services.WebhookSubscriptionUpdateParams{
+		ID:          c.Param("subscriptionID"),
+		UserID:      userID(c),
+		URL:         request.URL,
+		EventTypes:  request.EventTypes,
+		Expression:  request.Expression,
+		RetryPolicy: retryPolicy(request.MaxAttempts, request.InitialBackoff, request.MaxBackoff),
+	})
+	if isExpressionInvalid(err) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+	}
+	if isSubscriptionNotFound(err) {
+		return c.JSON(http.StatusNotFound, map[string]string{"message": err.Error()})
+	}
+	if err != nil {
+		msg := fmt.Sprintf("cannot update webhook subscription [%s]", c.Param("subscriptionID"))
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)).Error()})
+	}
+
+	return c.JSON(http.StatusOK, subscription)
+}
+
+// Index lists the webhook subscriptions belonging to the authenticated user
+// @Summary      List webhook subscriptions
+// @Tags         Webhooks
+// @Produce      json
+// @Success      200 {array} entities.WebhookSubscription
+// @Router       /webhook-subscriptions [get]
+func (h *WebhookHandler) Index(c echo.Context) error {
+	ctx, span := h.tracer.Start(c.Request().Context())
+	defer span.End()
+
+	subscriptions, err := h.service.GetSubscriptions(ctx, userID(c))
+	if err != nil {
+		msg := "cannot load webhook subscriptions"
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)).Error()})
+	}
+
+	return c.JSON(http.StatusOK, subscriptions)
+}
+
+// Delete removes a webhook subscription
+// @Summary      Delete a webhook subscription
+// @Tags         Webhooks
+// @Produce      json
+// @Param        subscriptionID path string true "Subscription ID"
+// @Success      204
+// @Router       /webhook-subscriptions/{subscriptionID} [delete]
+func (h *WebhookHandler) Delete(c echo.Context) error {
+	ctx, span := h.tracer.Start(c.Request().Context())
+	defer span.End()
+
+	id, err := uuid.Parse(c.Param("subscriptionID"))
+	if err != nil {
+		msg := fmt.Sprintf("cannot parse [%s] as a UUID", c.Param("subscriptionID"))
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)).Error()})
+	}
+
+	if err = h.service.DeleteSubscription(ctx, userID(c), id); err != nil {
+		if isSubscriptionNotFound(err) {
+			return c.JSON(http.StatusNotFound, map[string]string{"message": err.Error()})
+		}
+		msg := fmt.Sprintf("cannot delete webhook subscription [%s]", id)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)).Error()})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// IndexDeliveries lists the delivery attempts for a webhook subscription
+// @Summary      List webhook delivery attempts
+// @Tags         Webhooks
+// @Produce      json
+// @Param        subscriptionID path string true "Subscription ID"
+// @Success      200 {array} entities.WebhookDeliveryAttempt
+// @Router       /webhook-subscriptions/{subscriptionID}/deliveries [get]
+func (h *WebhookHandler) IndexDeliveries(c echo.Context) error {
+	ctx, span := h.tracer.Start(c.Request().Context())
+	defer span.End()
+
+	id, err := uuid.Parse(c.Param("subscriptionID"))
+	if err != nil {
+		msg := fmt.Sprintf("cannot parse [%s] as a UUID", c.Param("subscriptionID"))
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)).Error()})
+	}
+
+	attempts, err := h.service.GetDeliveryAttempts(ctx, userID(c), id)
+	if err != nil {
+		if isSubscriptionNotFound(err) {
+			return c.JSON(http.StatusNotFound, map[string]string{"message": err.Error()})
+		}
+		msg := fmt.Sprintf("cannot load delivery attempts for webhook subscription [%s]", id)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)).Error()})
+	}
+
+	return c.JSON(http.StatusOK, attempts)
+}
+
+// isExpressionInvalid reports whether err is (or wraps, via stacktrace.Propagate) services.ErrWebhookExpressionInvalid.
+// stacktrace's error type implements neither Unwrap nor Is, so errors.Is can't see through a stacktrace.Propagate
+// wrapper directly; stacktrace.RootCause peels the stacktrace layers back to the fmt.Errorf("%w: ...", ...) underneath,
+// which does support errors.Is
+func isExpressionInvalid(err error) bool {
+	return errors.Is(stacktrace.RootCause(err), services.ErrWebhookExpressionInvalid)
+}
+
+// isSubscriptionNotFound reports whether err is (or wraps, via stacktrace.Propagate) services.ErrWebhookSubscriptionNotFound
+func isSubscriptionNotFound(err error) bool {
+	return errors.Is(stacktrace.RootCause(err), services.ErrWebhookSubscriptionNotFound)
+}
+
+// userID extracts the authenticated user's ID set on the echo context by the auth middleware
+func userID(c echo.Context) string {
+	if id, ok := c.Get("userID").(string); ok {
+		return id
+	}
+	return ""
+}
+
+// retryPolicy builds an entities.WebhookRetryPolicy from request fields, falling back to sensible defaults when unset
+func retryPolicy(maxAttempts, initialBackoffSeconds, maxBackoffSeconds int) entities.WebhookRetryPolicy {
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	if initialBackoffSeconds <= 0 {
+		initialBackoffSeconds = 1
+	}
+	if maxBackoffSeconds <= 0 {
+		maxBackoffSeconds = 60
+	}
+
+	return entities.WebhookRetryPolicy{
+		MaxAttempts:    maxAttempts,
+		InitialBackoff: time.Duration(initialBackoffSeconds) * time.Second,
+		MaxBackoff:     time.Duration(maxBackoffSeconds) * time.Second,
+	}
+}
@@ -0,0 +1,21 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/NdoleStudio/http-sms-manager/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// MessageRepository loads and persists entities.Message
+type MessageRepository interface {
+	// Save a new message or update an existing one
+	Save(ctx context.Context, message *entities.Message) error
+
+	// Load a message by ID
+	Load(ctx context.Context, id uuid.UUID) (*entities.Message, error)
+
+	// LoadExpired returns every MessageStatusPending message whose ExpiresAt is before cutoff, for the deadline reaper to sweep up messages whose in-process timer was lost to a restart
+	LoadExpired(ctx context.Context, cutoff time.Time) ([]*entities.Message, error)
+}
@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/http-sms-manager/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// WebhookSubscriptionRepository loads and persists entities.WebhookSubscription and their delivery attempts
+type WebhookSubscriptionRepository interface {
+	// Save a new subscription or update an existing one
+	Save(ctx context.Context, subscription *entities.WebhookSubscription) error
+
+	// Load a subscription by ID
+	Load(ctx context.Context, id uuid.UUID) (*entities.WebhookSubscription, error)
+
+	// Index returns every subscription belonging to userID
+	Index(ctx context.Context, userID string) ([]*entities.WebhookSubscription, error)
+
+	// Delete a subscription by ID
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// SaveDeliveryAttempt records a WebhookDeliveryAttempt
+	SaveDeliveryAttempt(ctx context.Context, attempt *entities.WebhookDeliveryAttempt) error
+
+	// IndexDeliveryAttempts returns the delivery attempts for subscriptionID, most recent first
+	IndexDeliveryAttempts(ctx context.Context, subscriptionID uuid.UUID) ([]*entities.WebhookDeliveryAttempt, error)
+}
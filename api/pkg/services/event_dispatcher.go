@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// EventHandler is invoked with every event received by an EventDispatcher
+type EventHandler func(ctx context.Context, event cloudevents.Event) error
+
+// EventDispatcher publishes a cloudevents.Event on a transport and fans events received from that transport out to registered EventHandlers
+type EventDispatcher interface {
+	// Dispatch publishes event on the underlying transport
+	Dispatch(ctx context.Context, event cloudevents.Event) error
+
+	// Subscribe registers handler to be invoked for every event received from the underlying transport
+	Subscribe(handler EventHandler)
+}
+
+// EventDispatcherDriver selects which EventDispatcher implementation NewEventDispatcher builds
+type EventDispatcherDriver string
+
+const (
+	// EventDispatcherDriverInProcess dispatches events to handlers in the same process, without a broker
+	EventDispatcherDriverInProcess EventDispatcherDriver = "inproc"
+
+	// EventDispatcherDriverNATS dispatches events through a NATS subject
+	EventDispatcherDriverNATS EventDispatcherDriver = "nats"
+
+	// EventDispatcherDriverKafka dispatches events through a Kafka topic
+	EventDispatcherDriverKafka EventDispatcherDriver = "kafka"
+
+	// EventDispatcherDriverPubsub dispatches events through a Google Cloud Pub/Sub topic
+	EventDispatcherDriverPubsub EventDispatcherDriver = "pubsub"
+)
+
+// handlerRegistry is embedded by every EventDispatcher implementation so Subscribe and local fan-out are only written once
+type handlerRegistry struct {
+	handlers []EventHandler
+}
+
+// Subscribe registers handler to be invoked for every event received by the embedding EventDispatcher
+func (registry *handlerRegistry) Subscribe(handler EventHandler) {
+	registry.handlers = append(registry.handlers, handler)
+}
+
+// dispatchLocal invokes every registered handler with event, stopping at the first error
+func (registry *handlerRegistry) dispatchLocal(ctx context.Context, event cloudevents.Event) error {
+	for _, handler := range registry.handlers {
+		if err := handler(ctx, event); err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot handle event [%s] with id [%s]", event.Type(), event.ID()))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/http-sms-manager/pkg/telemetry"
+)
+
+// EventDispatcherConfig holds the connection settings for every EventDispatcherDriver
+type EventDispatcherConfig struct {
+	Driver          EventDispatcherDriver
+	NATSURL         string
+	NATSSubject     string
+	KafkaBrokers    []string
+	KafkaTopic      string
+	KafkaGroupID    string
+	PubsubProjectID string
+	PubsubTopic     string
+}
+
+// NewEventDispatcher builds the EventDispatcher selected by config.Driver, e.g. via the EVENT_DISPATCHER_DRIVER environment variable
+func NewEventDispatcher(ctx context.Context, logger telemetry.Logger, tracer telemetry.Tracer, config EventDispatcherConfig) (EventDispatcher, error) {
+	switch config.Driver {
+	case EventDispatcherDriverNATS:
+		return NewNATSEventDispatcher(ctx, logger, tracer, config.NATSURL, config.NATSSubject)
+	case EventDispatcherDriverKafka:
+		return NewKafkaEventDispatcher(ctx, logger, tracer, config.KafkaBrokers, config.KafkaTopic, config.KafkaGroupID)
+	case EventDispatcherDriverPubsub:
+		return NewPubsubEventDispatcher(ctx, logger, tracer, config.PubsubProjectID, config.PubsubTopic)
+	case EventDispatcherDriverInProcess, "":
+		return NewInProcEventDispatcher(logger, tracer), nil
+	default:
+		return nil, fmt.Errorf("unknown event dispatcher driver [%s]", config.Driver)
+	}
+}
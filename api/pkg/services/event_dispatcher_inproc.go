@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+
+	"github.com/NdoleStudio/http-sms-manager/pkg/telemetry"
+)
+
+// InProcEventDispatcher is the EventDispatcher used when EVENT_DISPATCHER_DRIVER=inproc. It dispatches events directly to registered handlers within the same process, without a broker
+type InProcEventDispatcher struct {
+	handlerRegistry
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewInProcEventDispatcher creates a new InProcEventDispatcher
+func NewInProcEventDispatcher(logger telemetry.Logger, tracer telemetry.Tracer) (dispatcher *InProcEventDispatcher) {
+	return &InProcEventDispatcher{
+		logger: logger.WithService(fmt.Sprintf("%T", dispatcher)),
+		tracer: tracer,
+	}
+}
+
+// Dispatch sends event to every registered handler, stopping at the first error
+func (dispatcher *InProcEventDispatcher) Dispatch(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := dispatcher.tracer.Start(ctx)
+	defer span.End()
+
+	ctxLogger := dispatcher.tracer.CtxLogger(dispatcher.logger, span)
+
+	if err := dispatcher.dispatchLocal(ctx, event); err != nil {
+		return dispatcher.tracer.WrapErrorSpan(span, err)
+	}
+
+	ctxLogger.Info(fmt.Sprintf("dispatched event [%s] with id [%s] to [%d] handler(s)", event.Type(), event.ID(), len(dispatcher.handlers)))
+	return nil
+}
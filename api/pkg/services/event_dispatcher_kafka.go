@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Shopify/sarama"
+	cekafka "github.com/cloudevents/sdk-go/protocol/kafka_sarama/v2"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+
+	"github.com/NdoleStudio/http-sms-manager/pkg/telemetry"
+)
+
+// KafkaEventDispatcher is the EventDispatcher used when EVENT_DISPATCHER_DRIVER=kafka. It publishes and consumes events over a Kafka topic using the CloudEvents Kafka (sarama) protocol binding
+type KafkaEventDispatcher struct {
+	handlerRegistry
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	client cloudevents.Client
+}
+
+// NewKafkaEventDispatcher dials brokers, binds topic under groupID and starts a background receiver that hydrates consumed events into the handlers registered with Subscribe
+func NewKafkaEventDispatcher(ctx context.Context, logger telemetry.Logger, tracer telemetry.Tracer, brokers []string, topic string, groupID string) (dispatcher *KafkaEventDispatcher, err error) {
+	protocol, err := cekafka.NewProtocol(brokers, sarama.NewConfig(), topic, topic, cekafka.WithReceiverGroupId(groupID))
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot create kafka protocol for topic [%s]", topic))
+	}
+
+	client, err := cloudevents.NewClient(protocol)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot create cloudevents client for kafka topic [%s]", topic))
+	}
+
+	dispatcher = &KafkaEventDispatcher{
+		logger: logger.WithService(fmt.Sprintf("%T", dispatcher)),
+		tracer: tracer,
+		client: client,
+	}
+
+	go dispatcher.receive(ctx)
+	return dispatcher, nil
+}
+
+// Dispatch publishes event on the Kafka topic this dispatcher is bound to
+func (dispatcher *KafkaEventDispatcher) Dispatch(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := dispatcher.tracer.Start(ctx)
+	defer span.End()
+
+	ctxLogger := dispatcher.tracer.CtxLogger(dispatcher.logger, span)
+
+	if result := dispatcher.client.Send(ctx, event); cloudevents.IsUndelivered(result) {
+		msg := fmt.Sprintf("cannot publish event [%s] with id [%s] to kafka", event.Type(), event.ID())
+		return dispatcher.tracer.WrapErrorSpan(span, stacktrace.Propagate(result, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("published event [%s] with id [%s] to kafka", event.Type(), event.ID()))
+	return nil
+}
+
+// receive starts the blocking Kafka receive loop, hydrating every consumed event into the registered handlers
+func (dispatcher *KafkaEventDispatcher) receive(ctx context.Context) {
+	if err := dispatcher.client.StartReceiver(ctx, dispatcher.dispatchLocal); err != nil {
+		dispatcher.logger.Error(stacktrace.Propagate(err, "kafka receiver stopped"))
+	}
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	cenats "github.com/cloudevents/sdk-go/protocol/nats/v2"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+
+	"github.com/NdoleStudio/http-sms-manager/pkg/telemetry"
+)
+
+// NATSEventDispatcher is the EventDispatcher used when EVENT_DISPATCHER_DRIVER=nats. It publishes and consumes events over a NATS subject using the CloudEvents NATS protocol binding
+type NATSEventDispatcher struct {
+	handlerRegistry
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	client cloudevents.Client
+}
+
+// NewNATSEventDispatcher dials url, binds subject and starts a background receiver that hydrates consumed events into the handlers registered with Subscribe
+func NewNATSEventDispatcher(ctx context.Context, logger telemetry.Logger, tracer telemetry.Tracer, url string, subject string) (dispatcher *NATSEventDispatcher, err error) {
+	protocol, err := cenats.NewProtocol(url, subject, subject, nil)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot create nats protocol for url [%s] and subject [%s]", url, subject))
+	}
+
+	client, err := cloudevents.NewClient(protocol)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot create cloudevents client for nats url [%s]", url))
+	}
+
+	dispatcher = &NATSEventDispatcher{
+		logger: logger.WithService(fmt.Sprintf("%T", dispatcher)),
+		tracer: tracer,
+		client: client,
+	}
+
+	go dispatcher.receive(ctx)
+	return dispatcher, nil
+}
+
+// Dispatch publishes event on the NATS subject this dispatcher is bound to
+func (dispatcher *NATSEventDispatcher) Dispatch(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := dispatcher.tracer.Start(ctx)
+	defer span.End()
+
+	ctxLogger := dispatcher.tracer.CtxLogger(dispatcher.logger, span)
+
+	if result := dispatcher.client.Send(ctx, event); cloudevents.IsUndelivered(result) {
+		msg := fmt.Sprintf("cannot publish event [%s] with id [%s] to nats", event.Type(), event.ID())
+		return dispatcher.tracer.WrapErrorSpan(span, stacktrace.Propagate(result, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("published event [%s] with id [%s] to nats", event.Type(), event.ID()))
+	return nil
+}
+
+// receive starts the blocking NATS receive loop, hydrating every consumed event into the registered handlers
+func (dispatcher *NATSEventDispatcher) receive(ctx context.Context) {
+	if err := dispatcher.client.StartReceiver(ctx, dispatcher.dispatchLocal); err != nil {
+		dispatcher.logger.Error(stacktrace.Propagate(err, "nats receiver stopped"))
+	}
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	cepubsub "github.com/cloudevents/sdk-go/protocol/pubsub/v2"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+
+	"github.com/NdoleStudio/http-sms-manager/pkg/telemetry"
+)
+
+// PubsubEventDispatcher is the EventDispatcher used when EVENT_DISPATCHER_DRIVER=pubsub. It publishes and consumes events over a Google Cloud Pub/Sub topic using the CloudEvents Pub/Sub protocol binding
+type PubsubEventDispatcher struct {
+	handlerRegistry
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	client cloudevents.Client
+}
+
+// NewPubsubEventDispatcher binds projectID/topic and starts a background receiver that hydrates consumed events into the handlers registered with Subscribe
+func NewPubsubEventDispatcher(ctx context.Context, logger telemetry.Logger, tracer telemetry.Tracer, projectID string, topic string) (dispatcher *PubsubEventDispatcher, err error) {
+	protocol, err := cepubsub.New(ctx, cepubsub.WithProjectID(projectID), cepubsub.WithTopicID(topic))
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot create pubsub protocol for project [%s] and topic [%s]", projectID, topic))
+	}
+
+	client, err := cloudevents.NewClient(protocol)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot create cloudevents client for pubsub topic [%s]", topic))
+	}
+
+	dispatcher = &PubsubEventDispatcher{
+		logger: logger.WithService(fmt.Sprintf("%T", dispatcher)),
+		tracer: tracer,
+		client: client,
+	}
+
+	go dispatcher.receive(ctx)
+	return dispatcher, nil
+}
+
+// Dispatch publishes event on the Pub/Sub topic this dispatcher is bound to
+func (dispatcher *PubsubEventDispatcher) Dispatch(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := dispatcher.tracer.Start(ctx)
+	defer span.End()
+
+	ctxLogger := dispatcher.tracer.CtxLogger(dispatcher.logger, span)
+
+	if result := dispatcher.client.Send(ctx, event); cloudevents.IsUndelivered(result) {
+		msg := fmt.Sprintf("cannot publish event [%s] with id [%s] to pubsub", event.Type(), event.ID())
+		return dispatcher.tracer.WrapErrorSpan(span, stacktrace.Propagate(result, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("published event [%s] with id [%s] to pubsub", event.Type(), event.ID()))
+	return nil
+}
+
+// receive starts the blocking Pub/Sub receive loop, hydrating every consumed event into the registered handlers
+func (dispatcher *PubsubEventDispatcher) receive(ctx context.Context) {
+	if err := dispatcher.client.StartReceiver(ctx, dispatcher.dispatchLocal); err != nil {
+		dispatcher.logger.Error(stacktrace.Propagate(err, "pubsub receiver stopped"))
+	}
+}
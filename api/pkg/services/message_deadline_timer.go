@@ -0,0 +1,73 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// deadlineTimer arms a cancellation channel to close when a deadline elapses. It is modeled on the cancel-channel/timer
+// pattern netstack's transport endpoints use for per-operation deadlines: setDeadline stops the previously armed timer
+// and closes its channel before arming a fresh one, and wait loops until the channel it observes closing is still the
+// current one, so repeatedly moving the deadline never leaves a stale timer or a blocked goroutine behind. Each
+// channel's close is guarded by a sync.Once shared with the timer's own AfterFunc callback, since Stop can race a
+// timer that is already firing: whichever of the two reaches the channel first closes it, the other is a no-op
+type deadlineTimer struct {
+	mu     sync.Mutex
+	timer  *time.Timer
+	cancel chan struct{}
+	once   *sync.Once
+}
+
+// newDeadlineTimer creates a disarmed deadlineTimer
+func newDeadlineTimer() *deadlineTimer {
+	return &deadlineTimer{cancel: make(chan struct{}), once: new(sync.Once)}
+}
+
+// setDeadline (re)arms the timer to close a fresh cancel channel when deadline elapses. A zero deadline disarms it.
+// Any timer and channel armed by a previous call are stopped and closed first, so a caller blocked in wait on the
+// previous channel wakes up, observes it has been superseded, and starts waiting on the new one instead
+func (d *deadlineTimer) setDeadline(deadline time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	d.once.Do(func() { close(d.cancel) })
+
+	d.cancel = make(chan struct{})
+	d.once = new(sync.Once)
+	cancel, once := d.cancel, d.once
+
+	if deadline.IsZero() {
+		return
+	}
+
+	if until := time.Until(deadline); until <= 0 {
+		once.Do(func() { close(cancel) })
+	} else {
+		d.timer = time.AfterFunc(until, func() { once.Do(func() { close(cancel) }) })
+	}
+}
+
+// wait blocks until the deadline armed by the most recent setDeadline call elapses, returning true. If setDeadline is
+// called again before that happens, wait transparently starts waiting on the new deadline instead of returning, so a
+// caller never wakes up on a channel that was superseded before it could fire
+func (d *deadlineTimer) wait() bool {
+	for {
+		d.mu.Lock()
+		cancel := d.cancel
+		d.mu.Unlock()
+
+		<-cancel
+
+		d.mu.Lock()
+		current := cancel == d.cancel
+		d.mu.Unlock()
+
+		if current {
+			return true
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MessageSendParams are the parameters for MessageService.SendMessage
+type MessageSendParams struct {
+	Source            string
+	UserID            string
+	From              string
+	To                string
+	Content           string
+	RequestReceivedAt time.Time
+
+	// Deadline is the absolute time by which the message must leave MessageStatusPending, after which the reaper marks it MessageStatusExpired. Takes precedence over TTL when both are set
+	Deadline time.Time
+
+	// TTL is a deadline expressed relative to now; ignored when Deadline is set
+	TTL time.Duration
+}
+
+// MessageStoreParams are the parameters for MessageService.StoreMessage
+type MessageStoreParams struct {
+	ID                uuid.UUID
+	UserID            string
+	From              string
+	To                string
+	Content           string
+	RequestReceivedAt time.Time
+
+	// RawPayload skips CloudEvents envelope construction and dispatch, saving the message directly to the repository. Set for high-throughput bulk MO ingestion from carrier gateways that already deliver in a fixed schema
+	RawPayload bool
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/NdoleStudio/http-sms-manager/pkg/events"
@@ -19,8 +20,9 @@ import (
 type MessageService struct {
 	logger          telemetry.Logger
 	tracer          telemetry.Tracer
-	eventDispatcher *EventDispatcher
+	eventDispatcher EventDispatcher
 	repository      repositories.MessageRepository
+	deadlines       sync.Map // uuid.UUID -> *deadlineTimer, tracks messages with a live send deadline
 }
 
 // NewMessageService creates a new MessageService
@@ -28,7 +30,7 @@ func NewMessageService(
 	logger telemetry.Logger,
 	tracer telemetry.Tracer,
 	repository repositories.MessageRepository,
-	eventDispatcher *EventDispatcher,
+	eventDispatcher EventDispatcher,
 ) (s *MessageService) {
 	return &MessageService{
 		logger:          logger.WithService(fmt.Sprintf("%T", s)),
@@ -47,23 +49,33 @@ func (service *MessageService) SendMessage(ctx context.Context, params MessageSe
 
 	eventPayload := events.MessageAPISentPayload{
 		ID:                uuid.New(),
+		UserID:            params.UserID,
 		From:              params.From,
 		To:                params.To,
 		RequestReceivedAt: params.RequestReceivedAt,
 		Content:           params.Content,
 	}
 
+	deadline := sendDeadline(params)
+
 	ctxLogger.Info(fmt.Sprintf("creating cloud event for message with ID [%s]", eventPayload.ID))
 
-	event, err := service.createMessageAPISentEvent(params.Source, eventPayload)
+	event, err := service.createMessageAPISentEvent(params.Source, eventPayload, deadline)
 	if err != nil {
-		msg := fmt.Sprintf("cannot create %T from payload with message id [%s]", event)
+		msg := fmt.Sprintf("cannot create %T from payload with message id [%s]", event, eventPayload.ID)
 		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
 	ctxLogger.Info(fmt.Sprintf("created event [%s] with id [%s] and message id [%s]", event.Type(), event.ID(), eventPayload.ID))
 
-	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
+	dispatchCtx := ctx
+	if !deadline.IsZero() {
+		var cancel context.CancelFunc
+		dispatchCtx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+
+	if err = service.eventDispatcher.Dispatch(dispatchCtx, event); err != nil {
 		msg := fmt.Sprintf("cannot dispatch event type [%s] and id [%s]", event.Type(), event.ID())
 		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
@@ -78,9 +90,27 @@ func (service *MessageService) SendMessage(ctx context.Context, params MessageSe
 
 	ctxLogger.Info(fmt.Sprintf("fetched message with id [%s] from the repository", message.ID))
 
+	if !deadline.IsZero() {
+		if err = service.armDeadline(ctx, message, deadline); err != nil {
+			msg := fmt.Sprintf("cannot arm send deadline for message with id [%s]", message.ID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+	}
+
 	return message, nil
 }
 
+// sendDeadline resolves the effective send deadline for params, preferring the absolute Deadline over the relative TTL
+func sendDeadline(params MessageSendParams) time.Time {
+	if !params.Deadline.IsZero() {
+		return params.Deadline
+	}
+	if params.TTL > 0 {
+		return time.Now().UTC().Add(params.TTL)
+	}
+	return time.Time{}
+}
+
 // StoreMessage a new message
 func (service *MessageService) StoreMessage(ctx context.Context, params MessageStoreParams) (*entities.Message, error) {
 	ctx, span := service.tracer.Start(ctx)
@@ -90,6 +120,7 @@ func (service *MessageService) StoreMessage(ctx context.Context, params MessageS
 
 	message := &entities.Message{
 		ID:                params.ID,
+		UserID:            params.UserID,
 		From:              params.From,
 		To:                params.To,
 		Content:           params.Content,
@@ -111,16 +142,59 @@ func (service *MessageService) StoreMessage(ctx context.Context, params MessageS
 	}
 
 	ctxLogger.Info(fmt.Sprintf("message saved with id [%s] in the repository", message.ID))
+
+	if params.RawPayload {
+		ctxLogger.Info(fmt.Sprintf("skipping cloud event dispatch for message [%s] because rawPayload=true", message.ID))
+		return message, nil
+	}
+
+	event, err := service.createMessageReceivedEvent(message)
+	if err != nil {
+		msg := fmt.Sprintf("cannot create %T from message with id [%s]", event, message.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event type [%s] and id [%s]", event.Type(), event.ID())
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("event [%s] dispatched succesfully", event.ID()))
 	return message, nil
 }
 
-func (service *MessageService) createMessageAPISentEvent(source string, payload events.MessageAPISentPayload) (event cloudevents.Event, err error) {
+// createMessageReceivedEvent wraps message in a cloudevents.Event of type events.EventTypeMessageReceived
+func (service *MessageService) createMessageReceivedEvent(message *entities.Message) (event cloudevents.Event, err error) {
+	event = cloudevents.NewEvent()
+
+	event.SetSource(message.ID.String())
+	event.SetType(events.EventTypeMessageReceived)
+	event.SetTime(time.Now().UTC())
+	event.SetID(uuid.New().String())
+	event.SetExtension("userid", message.UserID)
+
+	if err = event.SetData(cloudevents.ApplicationJSON, message); err != nil {
+		msg := fmt.Sprintf("cannot encode %T [%#+v] as JSON", message, message)
+		return event, stacktrace.Propagate(err, msg)
+	}
+
+	return event, nil
+}
+
+// createMessageAPISentEvent wraps payload in a cloudevents.Event of type events.EventTypeMessageAPISent. When deadline
+// is non-zero, the event carries a "ttl" extension attribute with the number of seconds until deadline elapses
+func (service *MessageService) createMessageAPISentEvent(source string, payload events.MessageAPISentPayload, deadline time.Time) (event cloudevents.Event, err error) {
 	event = cloudevents.NewEvent()
 
 	event.SetSource(source)
 	event.SetType(events.EventTypeMessageAPISent)
 	event.SetTime(time.Now().UTC())
 	event.SetID(uuid.New().String())
+	event.SetExtension("userid", payload.UserID)
+
+	if !deadline.IsZero() {
+		event.SetExtension("ttl", int64(time.Until(deadline).Seconds()))
+	}
 
 	if err = event.SetData(cloudevents.ApplicationJSON, payload); err != nil {
 		msg := fmt.Sprintf("cannot encode %T [%#+v] as JSON", payload, payload)
@@ -129,3 +203,141 @@ func (service *MessageService) createMessageAPISentEvent(source string, payload
 
 	return event, nil
 }
+
+// createMessageExpiredEvent wraps message in a cloudevents.Event of type events.EventTypeMessageExpired
+func (service *MessageService) createMessageExpiredEvent(message *entities.Message) (event cloudevents.Event, err error) {
+	event = cloudevents.NewEvent()
+
+	event.SetSource(message.ID.String())
+	event.SetType(events.EventTypeMessageExpired)
+	event.SetTime(time.Now().UTC())
+	event.SetID(uuid.New().String())
+	event.SetExtension("userid", message.UserID)
+
+	if err = event.SetData(cloudevents.ApplicationJSON, message); err != nil {
+		msg := fmt.Sprintf("cannot encode %T [%#+v] as JSON", message, message)
+		return event, stacktrace.Propagate(err, msg)
+	}
+
+	return event, nil
+}
+
+// armDeadline persists message.ExpiresAt and (re)arms the deadlineTimer tracking message, starting its reaper
+// goroutine the first time a deadline is set for message.ID
+func (service *MessageService) armDeadline(ctx context.Context, message *entities.Message, deadline time.Time) error {
+	message.ExpiresAt = nil
+	if !deadline.IsZero() {
+		message.ExpiresAt = &deadline
+	}
+	if err := service.repository.Save(ctx, message); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot save expires_at for message with id [%s]", message.ID))
+	}
+
+	value, loaded := service.deadlines.LoadOrStore(message.ID, newDeadlineTimer())
+	timer := value.(*deadlineTimer)
+	timer.setDeadline(deadline)
+
+	if !loaded {
+		go service.watchDeadline(message.ID, timer)
+	}
+
+	return nil
+}
+
+// UpdateSendDeadline moves the send deadline for an in-flight message, cancelling whatever timer was previously
+// armed for it. A zero deadline pauses the reaper for messageID without losing track of it
+func (service *MessageService) UpdateSendDeadline(ctx context.Context, messageID uuid.UUID, deadline time.Time) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	message, err := service.repository.Load(ctx, messageID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load message with id [%s]", messageID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.armDeadline(ctx, message, deadline); err != nil {
+		return service.tracer.WrapErrorSpan(span, err)
+	}
+
+	return nil
+}
+
+// StartExpiryReaper polls MessageRepository.LoadExpired every interval and reaps whatever it finds, until ctx is
+// cancelled. This is the fallback path for deadlines that elapsed while no process held the in-memory deadlineTimer
+// for them, e.g. because the process restarted between SendMessage and the deadline firing
+func (service *MessageService) StartExpiryReaper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			service.reapExpiredMessages(ctx)
+		}
+	}
+}
+
+// reapExpiredMessages loads every message past its deadline and reaps it
+func (service *MessageService) reapExpiredMessages(ctx context.Context) {
+	messages, err := service.repository.LoadExpired(ctx, time.Now().UTC())
+	if err != nil {
+		service.logger.Error(stacktrace.Propagate(err, "cannot load expired messages"))
+		return
+	}
+
+	for _, message := range messages {
+		service.reapExpiry(ctx, message.ID)
+	}
+}
+
+// watchDeadline blocks until timer genuinely expires (as opposed to being superseded by a new deadline) and then
+// reaps messageID. It is started once per message, for as long as the process is alive; a restart relies on
+// MessageRepository.LoadExpired to sweep up deadlines that elapsed while no process was watching
+func (service *MessageService) watchDeadline(messageID uuid.UUID, timer *deadlineTimer) {
+	timer.wait()
+	service.deadlines.Delete(messageID)
+	service.reapExpiry(context.Background(), messageID)
+}
+
+// reapExpiry marks messageID as MessageStatusExpired and emits events.EventTypeMessageExpired, unless it has already
+// left MessageStatusPending
+func (service *MessageService) reapExpiry(ctx context.Context, messageID uuid.UUID) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	ctxLogger := service.tracer.CtxLogger(service.logger, span)
+
+	message, err := service.repository.Load(ctx, messageID)
+	if err != nil {
+		service.logger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot load message [%s] for the expiry reaper", messageID)))
+		return
+	}
+
+	if message.Status != entities.MessageStatusPending {
+		return
+	}
+
+	now := time.Now().UTC()
+	message.Status = entities.MessageStatusExpired
+	message.ExpiredAt = &now
+
+	if err = service.repository.Save(ctx, message); err != nil {
+		service.logger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot mark message [%s] as expired", messageID)))
+		return
+	}
+
+	ctxLogger.Info(fmt.Sprintf("message [%s] expired", messageID))
+
+	event, err := service.createMessageExpiredEvent(message)
+	if err != nil {
+		service.logger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot create expiry event for message [%s]", messageID)))
+		return
+	}
+
+	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
+		service.logger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot dispatch expiry event for message [%s]", messageID)))
+	}
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/http-sms-manager/pkg/entities"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/NdoleStudio/http-sms-manager/pkg/telemetry"
+)
+
+type noopLogger struct{}
+
+func (l *noopLogger) WithService(string) telemetry.Logger { return l }
+func (l *noopLogger) Debug(string)                        {}
+func (l *noopLogger) Info(string)                         {}
+func (l *noopLogger) Error(error)                         {}
+
+type noopTracer struct {
+	tracer trace.Tracer
+}
+
+func newNoopTracer() *noopTracer {
+	return &noopTracer{tracer: trace.NewNoopTracerProvider().Tracer("bench")}
+}
+
+func (t *noopTracer) Start(ctx context.Context) (context.Context, trace.Span) {
+	return t.tracer.Start(ctx, "span")
+}
+
+func (t *noopTracer) CtxLogger(logger telemetry.Logger, _ trace.Span) telemetry.Logger {
+	return logger
+}
+
+func (t *noopTracer) WrapErrorSpan(_ trace.Span, err error) error {
+	return err
+}
+
+type benchMessageRepository struct{}
+
+func (r *benchMessageRepository) Save(context.Context, *entities.Message) error {
+	return nil
+}
+
+func (r *benchMessageRepository) Load(context.Context, uuid.UUID) (*entities.Message, error) {
+	return &entities.Message{}, nil
+}
+
+func (r *benchMessageRepository) LoadExpired(context.Context, time.Time) ([]*entities.Message, error) {
+	return nil, nil
+}
+
+type benchEventDispatcher struct{}
+
+func (d *benchEventDispatcher) Dispatch(context.Context, cloudevents.Event) error {
+	return nil
+}
+
+func (d *benchEventDispatcher) Subscribe(EventHandler) {}
+
+// BenchmarkMessageService_StoreMessage compares the throughput of the CloudEvents path against the RawPayload bypass
+func BenchmarkMessageService_StoreMessage(b *testing.B) {
+	service := NewMessageService(&noopLogger{}, newNoopTracer(), &benchMessageRepository{}, &benchEventDispatcher{})
+	ctx := context.Background()
+
+	b.Run("cloudevents", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, _ = service.StoreMessage(ctx, MessageStoreParams{
+				ID:                uuid.New(),
+				From:              "+18005550100",
+				To:                "+18005550101",
+				Content:           "hello",
+				RequestReceivedAt: time.Now().UTC(),
+			})
+		}
+	})
+
+	b.Run("rawPayload", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, _ = service.StoreMessage(ctx, MessageStoreParams{
+				ID:                uuid.New(),
+				From:              "+18005550100",
+				To:                "+18005550101",
+				Content:           "hello",
+				RequestReceivedAt: time.Now().UTC(),
+				RawPayload:        true,
+			})
+		}
+	})
+}
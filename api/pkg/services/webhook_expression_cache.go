@@ -0,0 +1,93 @@
+package services
+
+import (
+	"sync"
+
+	cesql "github.com/cloudevents/sdk-go/sql/v2"
+	cesqlparser "github.com/cloudevents/sdk-go/sql/v2/parser"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+)
+
+// webhookExpressionCacheEntry pairs a compiled CESQL expression with the source string it was compiled from, so
+// matches can detect a stale entry left by a subscription's Expression changing since it was cached
+type webhookExpressionCacheEntry struct {
+	source     string
+	expression cesql.Expression
+}
+
+// webhookExpressionCache holds compiled CESQL expressions keyed by webhook subscription ID, so an expression is parsed once per distinct source string rather than on every event dispatched to it
+type webhookExpressionCache struct {
+	mu      sync.RWMutex
+	entries map[uuid.UUID]webhookExpressionCacheEntry
+}
+
+// newWebhookExpressionCache creates an empty webhookExpressionCache
+func newWebhookExpressionCache() *webhookExpressionCache {
+	return &webhookExpressionCache{
+		entries: make(map[uuid.UUID]webhookExpressionCacheEntry),
+	}
+}
+
+// put compiles expression and stores it under id, overwriting any expression previously cached for id. An empty expression clears the cache entry so the subscription matches every event. Called from CreateSubscription/UpdateSubscription so an invalid CESQL expression is rejected at write time
+func (cache *webhookExpressionCache) put(id uuid.UUID, expression string) error {
+	if expression == "" {
+		cache.mu.Lock()
+		delete(cache.entries, id)
+		cache.mu.Unlock()
+		return nil
+	}
+
+	compiled, err := cesqlparser.Parse(expression)
+	if err != nil {
+		return err
+	}
+
+	cache.mu.Lock()
+	cache.entries[id] = webhookExpressionCacheEntry{source: expression, expression: compiled}
+	cache.mu.Unlock()
+	return nil
+}
+
+// delete removes any expression cached for id
+func (cache *webhookExpressionCache) delete(id uuid.UUID) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	delete(cache.entries, id)
+}
+
+// matches evaluates expression against event, returning true when expression is empty. expression is the
+// subscription's current Expression field as loaded fresh from the repository by handleEvent, not whatever this
+// process last cached for id — so matches compiles and caches it on demand the first time it sees a given id/source
+// pair, rather than assuming put was already called for every subscription in this process. That keeps filtering
+// correct after a restart or in any instance of a horizontally-scaled deployment that never itself handled the
+// subscription's Create/Update call
+func (cache *webhookExpressionCache) matches(id uuid.UUID, expression string, event cloudevents.Event) bool {
+	if expression == "" {
+		return true
+	}
+
+	cache.mu.RLock()
+	entry, ok := cache.entries[id]
+	cache.mu.RUnlock()
+
+	if !ok || entry.source != expression {
+		compiled, err := cesqlparser.Parse(expression)
+		if err != nil {
+			return false
+		}
+
+		entry = webhookExpressionCacheEntry{source: expression, expression: compiled}
+		cache.mu.Lock()
+		cache.entries[id] = entry
+		cache.mu.Unlock()
+	}
+
+	result, err := entry.expression.Evaluate(event)
+	if err != nil {
+		return false
+	}
+
+	matched, ok := result.(bool)
+	return ok && matched
+}
@@ -0,0 +1,22 @@
+package services
+
+import "github.com/NdoleStudio/http-sms-manager/pkg/entities"
+
+// WebhookSubscriptionCreateParams are the parameters for creating a new webhook subscription
+type WebhookSubscriptionCreateParams struct {
+	UserID      string
+	URL         string
+	EventTypes  []string
+	Expression  string
+	RetryPolicy entities.WebhookRetryPolicy
+}
+
+// WebhookSubscriptionUpdateParams are the parameters for updating an existing webhook subscription
+type WebhookSubscriptionUpdateParams struct {
+	ID          string
+	UserID      string
+	URL         string
+	EventTypes  []string
+	Expression  string
+	RetryPolicy entities.WebhookRetryPolicy
+}
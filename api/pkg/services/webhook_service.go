@@ -0,0 +1,312 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/NdoleStudio/http-sms-manager/pkg/entities"
+	"github.com/NdoleStudio/http-sms-manager/pkg/repositories"
+	"github.com/NdoleStudio/http-sms-manager/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// signatureHeader carries the HMAC-SHA256 signature of a webhook delivery's body
+const signatureHeader = "X-HttpSms-Signature"
+
+// ErrWebhookExpressionInvalid is returned when a webhook subscription's CESQL Expression fails to parse
+var ErrWebhookExpressionInvalid = errors.New("webhook expression is not a valid CESQL expression")
+
+// ErrWebhookSubscriptionNotFound is returned when a webhook subscription does not belong to the caller. A subscription
+// that cannot be loaded, including one that does not exist, is reported with the repository's own error instead
+var ErrWebhookSubscriptionNotFound = errors.New("webhook subscription not found")
+
+// WebhookService handles CRUD operations on webhook subscriptions and fans out dispatched events to them
+type WebhookService struct {
+	logger      telemetry.Logger
+	tracer      telemetry.Tracer
+	repository  repositories.WebhookSubscriptionRepository
+	client      *http.Client
+	expressions *webhookExpressionCache
+}
+
+// NewWebhookService creates a new WebhookService and subscribes it to eventDispatcher so every dispatched event is fanned out to matching subscriptions
+func NewWebhookService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.WebhookSubscriptionRepository,
+	eventDispatcher EventDispatcher,
+) (service *WebhookService) {
+	service = &WebhookService{
+		logger:      logger.WithService(fmt.Sprintf("%T", service)),
+		tracer:      tracer,
+		repository:  repository,
+		client:      &http.Client{Timeout: 30 * time.Second},
+		expressions: newWebhookExpressionCache(),
+	}
+
+	eventDispatcher.Subscribe(service.handleEvent)
+	return service
+}
+
+// CreateSubscription registers a new webhook subscription
+func (service *WebhookService) CreateSubscription(ctx context.Context, params WebhookSubscriptionCreateParams) (*entities.WebhookSubscription, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	ctxLogger := service.tracer.CtxLogger(service.logger, span)
+
+	subscription := &entities.WebhookSubscription{
+		ID:            uuid.New(),
+		UserID:        params.UserID,
+		URL:           params.URL,
+		EventTypes:    params.EventTypes,
+		SigningSecret: uuid.NewString(),
+		Expression:    params.Expression,
+		RetryPolicy:   params.RetryPolicy,
+		CreatedAt:     time.Now().UTC(),
+		UpdatedAt:     time.Now().UTC(),
+	}
+
+	if err := service.expressions.put(subscription.ID, subscription.Expression); err != nil {
+		msg := fmt.Sprintf("cannot compile expression [%s] for webhook subscription [%s]", subscription.Expression, subscription.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(fmt.Errorf("%w: %s", ErrWebhookExpressionInvalid, err), msg))
+	}
+
+	if err := service.repository.Save(ctx, subscription); err != nil {
+		msg := fmt.Sprintf("cannot save webhook subscription for user [%s] and url [%s]", params.UserID, params.URL)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("webhook subscription [%s] saved for user [%s]", subscription.ID, params.UserID))
+	return subscription, nil
+}
+
+// UpdateSubscription updates an existing webhook subscription
+func (service *WebhookService) UpdateSubscription(ctx context.Context, params WebhookSubscriptionUpdateParams) (*entities.WebhookSubscription, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	ctxLogger := service.tracer.CtxLogger(service.logger, span)
+
+	id, err := uuid.Parse(params.ID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot parse [%s] as a UUID", params.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	subscription, err := service.loadOwnedSubscription(ctx, params.UserID, id)
+	if err != nil {
+		return nil, service.tracer.WrapErrorSpan(span, err)
+	}
+
+	subscription.URL = params.URL
+	subscription.EventTypes = params.EventTypes
+	subscription.Expression = params.Expression
+	subscription.RetryPolicy = params.RetryPolicy
+	subscription.UpdatedAt = time.Now().UTC()
+
+	if err = service.expressions.put(subscription.ID, subscription.Expression); err != nil {
+		msg := fmt.Sprintf("cannot compile expression [%s] for webhook subscription [%s]", subscription.Expression, subscription.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(fmt.Errorf("%w: %s", ErrWebhookExpressionInvalid, err), msg))
+	}
+
+	if err = service.repository.Save(ctx, subscription); err != nil {
+		msg := fmt.Sprintf("cannot save webhook subscription [%s]", subscription.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("webhook subscription [%s] updated", subscription.ID))
+	return subscription, nil
+}
+
+// GetSubscriptions returns every webhook subscription belonging to userID
+func (service *WebhookService) GetSubscriptions(ctx context.Context, userID string) ([]*entities.WebhookSubscription, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	subscriptions, err := service.repository.Index(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load webhook subscriptions for user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return subscriptions, nil
+}
+
+// DeleteSubscription removes a webhook subscription by ID, provided it belongs to userID
+func (service *WebhookService) DeleteSubscription(ctx context.Context, userID string, id uuid.UUID) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	ctxLogger := service.tracer.CtxLogger(service.logger, span)
+
+	if _, err := service.loadOwnedSubscription(ctx, userID, id); err != nil {
+		return service.tracer.WrapErrorSpan(span, err)
+	}
+
+	if err := service.repository.Delete(ctx, id); err != nil {
+		msg := fmt.Sprintf("cannot delete webhook subscription [%s]", id)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	service.expressions.delete(id)
+
+	ctxLogger.Info(fmt.Sprintf("webhook subscription [%s] deleted", id))
+	return nil
+}
+
+// GetDeliveryAttempts returns the delivery log for a webhook subscription, most recent first, provided it belongs to userID
+func (service *WebhookService) GetDeliveryAttempts(ctx context.Context, userID string, subscriptionID uuid.UUID) ([]*entities.WebhookDeliveryAttempt, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	if _, err := service.loadOwnedSubscription(ctx, userID, subscriptionID); err != nil {
+		return nil, service.tracer.WrapErrorSpan(span, err)
+	}
+
+	attempts, err := service.repository.IndexDeliveryAttempts(ctx, subscriptionID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load delivery attempts for webhook subscription [%s]", subscriptionID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return attempts, nil
+}
+
+// loadOwnedSubscription loads the subscription with id and returns ErrWebhookSubscriptionNotFound, rather than the
+// subscription, if it does not belong to userID
+func (service *WebhookService) loadOwnedSubscription(ctx context.Context, userID string, id uuid.UUID) (*entities.WebhookSubscription, error) {
+	subscription, err := service.repository.Load(ctx, id)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot load webhook subscription [%s]", id))
+	}
+
+	if subscription.UserID != userID {
+		msg := fmt.Sprintf("webhook subscription [%s] does not belong to user [%s]", id, userID)
+		return nil, stacktrace.Propagate(ErrWebhookSubscriptionNotFound, msg)
+	}
+
+	return subscription, nil
+}
+
+// handleEvent is the EventHandler registered with the EventDispatcher. It fans event out, asynchronously, to every matching subscription
+func (service *WebhookService) handleEvent(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	ctxLogger := service.tracer.CtxLogger(service.logger, span)
+
+	subscriptions, err := service.repository.Index(ctx, userIDFromEvent(event))
+	if err != nil {
+		msg := fmt.Sprintf("cannot load webhook subscriptions for event [%s]", event.ID())
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	for _, subscription := range subscriptions {
+		if !subscription.Matches(event.Type()) {
+			continue
+		}
+
+		if !service.expressions.matches(subscription.ID, subscription.Expression, event) {
+			continue
+		}
+
+		ctxLogger.Info(fmt.Sprintf("fanning out event [%s] to webhook subscription [%s]", event.ID(), subscription.ID))
+		go service.deliverWithRetry(context.Background(), subscription, event)
+	}
+
+	return nil
+}
+
+// deliverWithRetry POSTs event to subscription.URL, retrying with exponential backoff on 5xx responses or on any
+// delivery error, such as a timeout
+func (service *WebhookService) deliverWithRetry(ctx context.Context, subscription *entities.WebhookSubscription, event cloudevents.Event) {
+	backoff := subscription.RetryPolicy.InitialBackoff
+
+	for attempt := 1; attempt <= subscription.RetryPolicy.MaxAttempts; attempt++ {
+		statusCode, err := service.deliver(ctx, subscription, event)
+
+		service.recordAttempt(ctx, subscription, event, statusCode, err)
+
+		if err == nil && statusCode < http.StatusInternalServerError {
+			return
+		}
+
+		if attempt == subscription.RetryPolicy.MaxAttempts {
+			return
+		}
+
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > subscription.RetryPolicy.MaxBackoff {
+			backoff = subscription.RetryPolicy.MaxBackoff
+		}
+	}
+}
+
+// deliver POSTs event to subscription.URL in structured mode, signing the body with subscription.SigningSecret
+func (service *WebhookService) deliver(ctx context.Context, subscription *entities.WebhookSubscription, event cloudevents.Event) (int, error) {
+	body, err := event.MarshalJSON()
+	if err != nil {
+		return 0, stacktrace.Propagate(err, fmt.Sprintf("cannot marshal event [%s] as JSON", event.ID()))
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, stacktrace.Propagate(err, fmt.Sprintf("cannot create request for webhook subscription [%s]", subscription.ID))
+	}
+
+	request.Header.Set("Content-Type", "application/cloudevents+json")
+	request.Header.Set(signatureHeader, "sha256="+sign(subscription.SigningSecret, body))
+
+	response, err := service.client.Do(request)
+	if err != nil {
+		return 0, stacktrace.Propagate(err, fmt.Sprintf("cannot deliver event [%s] to webhook subscription [%s]", event.ID(), subscription.ID))
+	}
+	defer response.Body.Close()
+
+	return response.StatusCode, nil
+}
+
+// recordAttempt persists a WebhookDeliveryAttempt, logging failures rather than returning them since this runs off the request's error path
+func (service *WebhookService) recordAttempt(ctx context.Context, subscription *entities.WebhookSubscription, event cloudevents.Event, statusCode int, deliveryErr error) {
+	attempt := &entities.WebhookDeliveryAttempt{
+		ID:             uuid.New(),
+		SubscriptionID: subscription.ID,
+		EventID:        event.ID(),
+		EventType:      event.Type(),
+		StatusCode:     statusCode,
+		Success:        deliveryErr == nil && statusCode < http.StatusInternalServerError,
+		AttemptedAt:    time.Now().UTC(),
+	}
+	if deliveryErr != nil {
+		attempt.Error = deliveryErr.Error()
+	}
+
+	if err := service.repository.SaveDeliveryAttempt(ctx, attempt); err != nil {
+		service.logger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot save delivery attempt for webhook subscription [%s]", subscription.ID)))
+	}
+}
+
+// sign returns the hex-encoded HMAC-SHA256 signature of body keyed by secret
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// userIDFromEvent extracts the httpsms user ID extension attribute set on event
+func userIDFromEvent(event cloudevents.Event) string {
+	extensions := event.Extensions()
+	if userID, ok := extensions["userid"].(string); ok {
+		return userID
+	}
+	return ""
+}
@@ -0,0 +1,398 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/http-sms-manager/pkg/entities"
+	"github.com/NdoleStudio/http-sms-manager/pkg/events"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// fakeWebhookSubscriptionRepository is an in-memory repositories.WebhookSubscriptionRepository for tests
+type fakeWebhookSubscriptionRepository struct {
+	mu            sync.Mutex
+	subscriptions map[uuid.UUID]*entities.WebhookSubscription
+	attempts      []*entities.WebhookDeliveryAttempt
+}
+
+func newFakeWebhookSubscriptionRepository() *fakeWebhookSubscriptionRepository {
+	return &fakeWebhookSubscriptionRepository{
+		subscriptions: make(map[uuid.UUID]*entities.WebhookSubscription),
+	}
+}
+
+func (r *fakeWebhookSubscriptionRepository) Save(_ context.Context, subscription *entities.WebhookSubscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscriptions[subscription.ID] = subscription
+	return nil
+}
+
+func (r *fakeWebhookSubscriptionRepository) Load(_ context.Context, id uuid.UUID) (*entities.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	subscription, ok := r.subscriptions[id]
+	if !ok {
+		return nil, errors.New("webhook subscription not found")
+	}
+	return subscription, nil
+}
+
+func (r *fakeWebhookSubscriptionRepository) Index(_ context.Context, userID string) ([]*entities.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var subscriptions []*entities.WebhookSubscription
+	for _, subscription := range r.subscriptions {
+		if subscription.UserID == userID {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	return subscriptions, nil
+}
+
+func (r *fakeWebhookSubscriptionRepository) Delete(_ context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.subscriptions, id)
+	return nil
+}
+
+func (r *fakeWebhookSubscriptionRepository) SaveDeliveryAttempt(_ context.Context, attempt *entities.WebhookDeliveryAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts = append(r.attempts, attempt)
+	return nil
+}
+
+func (r *fakeWebhookSubscriptionRepository) IndexDeliveryAttempts(_ context.Context, subscriptionID uuid.UUID) ([]*entities.WebhookDeliveryAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var attempts []*entities.WebhookDeliveryAttempt
+	for _, attempt := range r.attempts {
+		if attempt.SubscriptionID == subscriptionID {
+			attempts = append(attempts, attempt)
+		}
+	}
+	return attempts, nil
+}
+
+func newTestWebhookService(repository *fakeWebhookSubscriptionRepository) *WebhookService {
+	return NewWebhookService(&noopLogger{}, newNoopTracer(), repository, &benchEventDispatcher{})
+}
+
+func TestWebhookService_UpdateSubscription_RejectsOtherUsersSubscription(t *testing.T) {
+	// Setup
+	repository := newFakeWebhookSubscriptionRepository()
+	service := newTestWebhookService(repository)
+	ctx := context.Background()
+
+	owner, err := service.CreateSubscription(ctx, WebhookSubscriptionCreateParams{
+		UserID:     "user-a",
+		URL:        "https://example.com/webhook",
+		EventTypes: []string{events.EventTypeMessageReceived},
+	})
+	if err != nil {
+		t.Fatalf("cannot create subscription: %s", err)
+	}
+
+	// Act
+	_, err = service.UpdateSubscription(ctx, WebhookSubscriptionUpdateParams{
+		ID:         owner.ID.String(),
+		UserID:     "user-b",
+		URL:        "https://attacker.example.com/webhook",
+		EventTypes: []string{events.EventTypeMessageReceived},
+	})
+
+	// Assert
+	if !errors.Is(stacktrace.RootCause(err), ErrWebhookSubscriptionNotFound) {
+		t.Fatalf("expected ErrWebhookSubscriptionNotFound, got %v", err)
+	}
+}
+
+func TestWebhookService_DeleteSubscription_RejectsOtherUsersSubscription(t *testing.T) {
+	// Setup
+	repository := newFakeWebhookSubscriptionRepository()
+	service := newTestWebhookService(repository)
+	ctx := context.Background()
+
+	owner, err := service.CreateSubscription(ctx, WebhookSubscriptionCreateParams{
+		UserID:     "user-a",
+		URL:        "https://example.com/webhook",
+		EventTypes: []string{events.EventTypeMessageReceived},
+	})
+	if err != nil {
+		t.Fatalf("cannot create subscription: %s", err)
+	}
+
+	// Act
+	err = service.DeleteSubscription(ctx, "user-b", owner.ID)
+
+	// Assert
+	if !errors.Is(stacktrace.RootCause(err), ErrWebhookSubscriptionNotFound) {
+		t.Fatalf("expected ErrWebhookSubscriptionNotFound, got %v", err)
+	}
+	if _, loadErr := repository.Load(ctx, owner.ID); loadErr != nil {
+		t.Fatalf("expected subscription [%s] to survive the rejected delete, got %s", owner.ID, loadErr)
+	}
+}
+
+func TestWebhookService_GetDeliveryAttempts_RejectsOtherUsersSubscription(t *testing.T) {
+	// Setup
+	repository := newFakeWebhookSubscriptionRepository()
+	service := newTestWebhookService(repository)
+	ctx := context.Background()
+
+	owner, err := service.CreateSubscription(ctx, WebhookSubscriptionCreateParams{
+		UserID:     "user-a",
+		URL:        "https://example.com/webhook",
+		EventTypes: []string{events.EventTypeMessageReceived},
+	})
+	if err != nil {
+		t.Fatalf("cannot create subscription: %s", err)
+	}
+
+	// Act
+	_, err = service.GetDeliveryAttempts(ctx, "user-b", owner.ID)
+
+	// Assert
+	if !errors.Is(stacktrace.RootCause(err), ErrWebhookSubscriptionNotFound) {
+		t.Fatalf("expected ErrWebhookSubscriptionNotFound, got %v", err)
+	}
+}
+
+func TestWebhookService_DeleteSubscription_AllowsOwner(t *testing.T) {
+	// Setup
+	repository := newFakeWebhookSubscriptionRepository()
+	service := newTestWebhookService(repository)
+	ctx := context.Background()
+
+	owner, err := service.CreateSubscription(ctx, WebhookSubscriptionCreateParams{
+		UserID:     "user-a",
+		URL:        "https://example.com/webhook",
+		EventTypes: []string{events.EventTypeMessageReceived},
+	})
+	if err != nil {
+		t.Fatalf("cannot create subscription: %s", err)
+	}
+
+	// Act
+	if err = service.DeleteSubscription(ctx, "user-a", owner.ID); err != nil {
+		t.Fatalf("expected owner delete to succeed, got %s", err)
+	}
+
+	// Assert
+	if _, loadErr := repository.Load(ctx, owner.ID); loadErr == nil {
+		t.Fatalf("expected subscription [%s] to be deleted", owner.ID)
+	}
+}
+
+func TestSign_IsHMACSHA256OfBody(t *testing.T) {
+	// Setup
+	secret := "s3cr3t"
+	body := []byte(`{"hello":"world"}`)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	want := hex.EncodeToString(mac.Sum(nil))
+
+	// Act
+	got := sign(secret, body)
+
+	// Assert
+	if got != want {
+		t.Fatalf("expected signature [%s], got [%s]", want, got)
+	}
+
+	if got == sign("other-secret", body) {
+		t.Fatalf("expected a different secret to produce a different signature")
+	}
+}
+
+func TestWebhookService_Deliver_SignsBodyWithSubscriptionSecret(t *testing.T) {
+	// Setup
+	var gotSignature, gotContentType string
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSignature = r.Header.Get(signatureHeader)
+		gotContentType = r.Header.Get("Content-Type")
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	repository := newFakeWebhookSubscriptionRepository()
+	service := newTestWebhookService(repository)
+
+	subscription := &entities.WebhookSubscription{
+		ID:            uuid.New(),
+		URL:           server.URL,
+		SigningSecret: "s3cr3t",
+	}
+
+	event := cloudevents.NewEvent()
+	event.SetID("evt-1")
+	event.SetSource("test")
+	event.SetType(events.EventTypeMessageReceived)
+	if err := event.SetData(cloudevents.ApplicationJSON, map[string]string{"hello": "world"}); err != nil {
+		t.Fatalf("cannot set event data: %s", err)
+	}
+
+	// Act
+	statusCode, err := service.deliver(context.Background(), subscription, event)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status [%d], got [%d]", http.StatusOK, statusCode)
+	}
+	if gotContentType != "application/cloudevents+json" {
+		t.Fatalf("unexpected content type [%s]", gotContentType)
+	}
+	if want := "sha256=" + sign(subscription.SigningSecret, gotBody); gotSignature != want {
+		t.Fatalf("expected signature [%s], got [%s]", want, gotSignature)
+	}
+}
+
+func TestWebhookService_DeliverWithRetry_StopsAfterFirstNon5xxResponse(t *testing.T) {
+	// Setup
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	repository := newFakeWebhookSubscriptionRepository()
+	service := newTestWebhookService(repository)
+
+	subscription := &entities.WebhookSubscription{
+		ID:            uuid.New(),
+		URL:           server.URL,
+		SigningSecret: "s3cr3t",
+		RetryPolicy: entities.WebhookRetryPolicy{
+			MaxAttempts:    5,
+			InitialBackoff: time.Millisecond,
+			MaxBackoff:     time.Millisecond,
+		},
+	}
+
+	event := cloudevents.NewEvent()
+	event.SetID("evt-1")
+	event.SetSource("test")
+	event.SetType(events.EventTypeMessageReceived)
+
+	// Act
+	service.deliverWithRetry(context.Background(), subscription, event)
+
+	// Assert
+	if attempts != 1 {
+		t.Fatalf("expected exactly 1 attempt, got %d", attempts)
+	}
+}
+
+func TestWebhookService_DeliverWithRetry_StopsAtMaxAttemptsOn5xx(t *testing.T) {
+	// Setup
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	repository := newFakeWebhookSubscriptionRepository()
+	service := newTestWebhookService(repository)
+
+	subscription := &entities.WebhookSubscription{
+		ID:            uuid.New(),
+		URL:           server.URL,
+		SigningSecret: "s3cr3t",
+		RetryPolicy: entities.WebhookRetryPolicy{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+			MaxBackoff:     time.Millisecond,
+		},
+	}
+
+	event := cloudevents.NewEvent()
+	event.SetID("evt-1")
+	event.SetSource("test")
+	event.SetType(events.EventTypeMessageReceived)
+
+	// Act
+	service.deliverWithRetry(context.Background(), subscription, event)
+
+	// Assert
+	if attempts != 3 {
+		t.Fatalf("expected exactly 3 attempts, got %d", attempts)
+	}
+}
+
+func TestWebhookExpressionCache_Matches(t *testing.T) {
+	// Setup
+	cache := newWebhookExpressionCache()
+	id := uuid.New()
+
+	event := cloudevents.NewEvent()
+	event.SetID("evt-1")
+	event.SetSource("test")
+	event.SetType(events.EventTypeMessageReceived)
+
+	tests := []struct {
+		name       string
+		expression string
+		want       bool
+	}{
+		{name: "empty expression matches everything", expression: "", want: true},
+		{name: "matching type", expression: `type = '` + events.EventTypeMessageReceived + `'`, want: true},
+		{name: "non-matching type", expression: `type = 'message.sent'`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got := cache.matches(id, tt.expression, event)
+
+			// Assert
+			if got != tt.want {
+				t.Fatalf("expected matches() to return %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWebhookExpressionCache_Matches_RecompilesWhenExpressionChanges(t *testing.T) {
+	// Setup
+	cache := newWebhookExpressionCache()
+	id := uuid.New()
+
+	event := cloudevents.NewEvent()
+	event.SetID("evt-1")
+	event.SetSource("test")
+	event.SetType(events.EventTypeMessageReceived)
+
+	if !cache.matches(id, `type = '`+events.EventTypeMessageReceived+`'`, event) {
+		t.Fatalf("expected first expression to match")
+	}
+
+	// Act
+	got := cache.matches(id, `type = 'message.sent'`, event)
+
+	// Assert
+	if got {
+		t.Fatalf("expected matches() to recompile for the new expression and return false")
+	}
+}
@@ -0,0 +1,16 @@
+package telemetry
+
+// Logger is a structured logger used across services and handlers so log lines can be correlated with a service name
+type Logger interface {
+	// WithService returns a Logger which tags every log line with service
+	WithService(service string) Logger
+
+	// Debug logs a debug level message
+	Debug(message string)
+
+	// Info logs an info level message
+	Info(message string)
+
+	// Error logs an error
+	Error(err error)
+}
@@ -0,0 +1,19 @@
+package telemetry
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracer starts and decorates spans for tracking the execution of requests across service boundaries
+type Tracer interface {
+	// Start a new span derived from ctx
+	Start(ctx context.Context) (context.Context, trace.Span)
+
+	// CtxLogger returns a Logger which annotates log lines with the trace and span IDs of span
+	CtxLogger(logger Logger, span trace.Span) Logger
+
+	// WrapErrorSpan records err on span and returns it unchanged so it can be returned from the caller
+	WrapErrorSpan(span trace.Span, err error) error
+}